@@ -1,13 +1,18 @@
 package terraform
 
 import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"sort"
 
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/terraform/internal/configs/configschema"
 	"github.com/hashicorp/terraform/internal/providers"
 	"github.com/hashicorp/terraform/internal/tfdiags"
 	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
 	ctyjson "github.com/zclconf/go-cty/cty/json"
 )
 
@@ -15,10 +20,14 @@ func dataResourceSchema() providers.Schema {
 	return providers.Schema{
 		Block: &configschema.Block{
 			Attributes: map[string]*configschema.Attribute{
-				"input":   {Type: cty.DynamicPseudoType, Optional: true},
-				"output":  {Type: cty.DynamicPseudoType, Computed: true},
-				"trigger": {Type: cty.DynamicPseudoType, Optional: true},
-				"uuid":    {Type: cty.String, Computed: true},
+				"id_mode":          {Type: cty.String, Optional: true},
+				"input":            {Type: cty.DynamicPseudoType, Optional: true},
+				"output":           {Type: cty.DynamicPseudoType, Computed: true},
+				"output_type":      {Type: cty.String, Optional: true},
+				"output_json":      {Type: cty.String, Computed: true},
+				"trigger":          {Type: cty.DynamicPseudoType, Optional: true},
+				"triggers_replace": {Type: cty.Map(cty.String), Optional: true},
+				"uuid":             {Type: cty.String, Computed: true},
 			},
 		},
 	}
@@ -31,11 +40,40 @@ func validateDataResourceConfig(req providers.ValidateResourceConfigRequest) (re
 
 	// Core does not currently validate computed values are not set in the
 	// configuration.
-	for _, attr := range []string{"uuid", "output"} {
+	for _, attr := range []string{"uuid", "output", "output_json"} {
 		if !req.Config.GetAttr(attr).IsNull() {
 			resp.Diagnostics = resp.Diagnostics.Append(fmt.Errorf(`%q attribute is read-only`, attr))
 		}
 	}
+
+	if !req.Config.GetAttr("trigger").IsNull() && !req.Config.GetAttr("triggers_replace").IsNull() {
+		resp.Diagnostics = resp.Diagnostics.Append(fmt.Errorf(`"trigger" and "triggers_replace" are mutually exclusive`))
+	}
+
+	// id_mode may be an unknown value at validate time (e.g. derived from
+	// another resource's attribute), in which case there's nothing to check
+	// yet; AsString panics on an unknown value.
+	if idMode := req.Config.GetAttr("id_mode"); !idMode.IsNull() && idMode.IsKnown() {
+		switch idMode.AsString() {
+		case "uuid", "sha256", "sha1":
+			// ok
+		default:
+			resp.Diagnostics = resp.Diagnostics.Append(fmt.Errorf(`"id_mode" must be one of "uuid", "sha256", or "sha1", got %q`, idMode.AsString()))
+		}
+	}
+
+	// output_type may likewise be unknown at validate time; nothing to
+	// check until it is.
+	if outputType := req.Config.GetAttr("output_type"); !outputType.IsNull() && outputType.IsKnown() {
+		declaredType, err := ctyjson.UnmarshalType([]byte(outputType.AsString()))
+		if err != nil {
+			resp.Diagnostics = resp.Diagnostics.Append(fmt.Errorf(`invalid "output_type": %s`, err))
+		} else if input := req.Config.GetAttr("input"); !input.IsNull() {
+			if _, err := convert.Convert(input, declaredType); err != nil {
+				resp.Diagnostics = resp.Diagnostics.Append(fmt.Errorf(`"input" does not conform to "output_type": %s`, err))
+			}
+		}
+	}
 	return resp
 }
 
@@ -67,43 +105,195 @@ func planDataResourceChange(req providers.PlanResourceChangeRequest) (resp provi
 
 	input := req.ProposedNewState.GetAttr("input")
 	trigger := req.ProposedNewState.GetAttr("trigger")
+	triggersReplace := req.ProposedNewState.GetAttr("triggers_replace")
+	idMode := req.ProposedNewState.GetAttr("id_mode")
 
-	switch {
-	case req.PriorState.IsNull():
+	if req.PriorState.IsNull() {
 		// Create
-		// Set the uuid value to unknown.
-		planned["uuid"] = cty.UnknownVal(cty.String)
+		// Set the uuid value, computing it now if id_mode allows a
+		// deterministic id to be known at plan time.
+		planned["uuid"] = plannedID(idMode, input, trigger, triggersReplace)
 
 		// Only compute a new output if input has a non-null value.
 		if !input.IsNull() {
 			planned["output"] = cty.UnknownVal(input.Type())
+			planned["output_json"] = cty.UnknownVal(cty.String)
 		}
 
 		resp.PlannedState = cty.ObjectVal(planned)
 		return resp
+	}
+
+	replace := false
 
-	case !req.PriorState.GetAttr("trigger").RawEquals(trigger):
+	if !req.PriorState.GetAttr("trigger").RawEquals(trigger) {
 		// trigger changed, so we need to replace the entire instance
 		resp.RequiresReplace = append(resp.RequiresReplace, cty.GetAttrPath("trigger"))
-		planned["uuid"] = cty.UnknownVal(cty.String)
+		replace = true
+	}
+
+	priorTriggersReplace := req.PriorState.GetAttr("triggers_replace")
+	switch {
+	case !triggersReplace.IsWhollyKnown() || !priorTriggersReplace.IsWhollyKnown():
+		// Not fully known yet (e.g. derived from another resource's
+		// attribute that hasn't been computed), so we can't diff it
+		// key-by-key. Conservatively force a replace, the same way a
+		// changed "trigger" does.
+		resp.RequiresReplace = append(resp.RequiresReplace, cty.GetAttrPath("triggers_replace"))
+		replace = true
+	default:
+		for _, key := range changedTriggerKeys(priorTriggersReplace, triggersReplace) {
+			resp.RequiresReplace = append(resp.RequiresReplace, cty.GetAttrPath("triggers_replace").IndexString(key))
+			replace = true
+		}
+	}
+
+	switch {
+	case replace:
+		planned["uuid"] = plannedID(idMode, input, trigger, triggersReplace)
 
 		// We need to check the input for the replacement instance to compute a
 		// new output.
 		if input.IsNull() {
 			planned["output"] = cty.NullVal(cty.DynamicPseudoType)
+			planned["output_json"] = cty.NullVal(cty.String)
 		} else {
 			planned["output"] = cty.UnknownVal(input.Type())
+			planned["output_json"] = cty.UnknownVal(cty.String)
+		}
+
+	case !req.PriorState.GetAttr("id_mode").RawEquals(idMode):
+		// id_mode itself changed, so the id must always be recomputed (or
+		// marked unknown) under the new mode, regardless of whether input
+		// also changed.
+		planned["uuid"] = plannedID(idMode, input, trigger, triggersReplace)
+
+		if !req.PriorState.GetAttr("input").RawEquals(input) {
+			planned["output"] = cty.UnknownVal(input.Type())
+			planned["output_json"] = cty.UnknownVal(cty.String)
+		} else if !req.PriorState.GetAttr("output_type").RawEquals(req.ProposedNewState.GetAttr("output_type")) {
+			planned["output_json"] = cty.UnknownVal(cty.String)
 		}
 
 	case !req.PriorState.GetAttr("input").RawEquals(input):
 		// only input changed, so we only need to re-compute output
 		planned["output"] = cty.UnknownVal(input.Type())
+		planned["output_json"] = cty.UnknownVal(cty.String)
+
+		// In a deterministic id_mode the id is derived from input, so it
+		// must be recomputed (or left unknown) alongside output. In the
+		// default "uuid" mode the id is independent of input and is left
+		// untouched. If id_mode itself isn't known yet, we can't tell which
+		// case applies, so the id has to be left unknown too.
+		switch {
+		case !idMode.IsKnown():
+			planned["uuid"] = cty.UnknownVal(cty.String)
+		case !idMode.IsNull() && idMode.AsString() != "uuid":
+			planned["uuid"] = plannedID(idMode, input, trigger, triggersReplace)
+		}
+
+	case !req.PriorState.GetAttr("output_type").RawEquals(req.ProposedNewState.GetAttr("output_type")):
+		// output_json is derived from input under the declared output_type,
+		// so a change to output_type alone still invalidates it even though
+		// input itself didn't change.
+		planned["output_json"] = cty.UnknownVal(cty.String)
 	}
 
 	resp.PlannedState = cty.ObjectVal(planned)
 	return resp
 }
 
+// plannedID determines the value to assign to the uuid attribute during
+// planning. The default "uuid" mode always leaves it unknown until apply,
+// since it's derived from a random UUID. The hash-based modes are
+// deterministic, so the id can be computed up front whenever the values
+// it's derived from are fully known, making the plan more informative.
+func plannedID(idMode, input, trigger, triggersReplace cty.Value) cty.Value {
+	mode := "uuid"
+	if !idMode.IsNull() {
+		if !idMode.IsKnown() {
+			// We won't know which mode applies until id_mode itself is
+			// known.
+			return cty.UnknownVal(cty.String)
+		}
+		mode = idMode.AsString()
+	}
+	if mode == "uuid" {
+		return cty.UnknownVal(cty.String)
+	}
+
+	if !input.IsWhollyKnown() || !trigger.IsWhollyKnown() || !triggersReplace.IsWhollyKnown() {
+		return cty.UnknownVal(cty.String)
+	}
+
+	id, err := hashID(mode, input, trigger, triggersReplace)
+	if err != nil {
+		return cty.UnknownVal(cty.String)
+	}
+	return cty.StringVal(id)
+}
+
+// hashID computes a stable, content-addressable id for input, trigger, and
+// triggers_replace under the given hash mode ("sha256" or "sha1"). The three
+// values are hashed together as a single structured object, rather than
+// concatenated independently, so that values like input=1/trigger=23 and
+// input=12/trigger=3 can't collide.
+func hashID(mode string, input, trigger, triggersReplace cty.Value) (string, error) {
+	obj := cty.ObjectVal(map[string]cty.Value{
+		"input":            input,
+		"trigger":          trigger,
+		"triggers_replace": triggersReplace,
+	})
+
+	j, err := ctyjson.Marshal(obj, obj.Type())
+	if err != nil {
+		return "", err
+	}
+
+	switch mode {
+	case "sha1":
+		sum := sha1.Sum(j)
+		return hex.EncodeToString(sum[:]), nil
+	default: // "sha256"
+		sum := sha256.Sum256(j)
+		return hex.EncodeToString(sum[:]), nil
+	}
+}
+
+// changedTriggerKeys compares the prior and proposed values of
+// triggers_replace and returns the sorted list of keys which were added,
+// removed, or changed. The keys are sorted so that the resulting
+// RequiresReplace paths are reported in a deterministic order.
+func changedTriggerKeys(prior, proposed cty.Value) []string {
+	priorMap := map[string]cty.Value{}
+	if !prior.IsNull() && prior.IsWhollyKnown() {
+		priorMap = prior.AsValueMap()
+	}
+	proposedMap := map[string]cty.Value{}
+	if !proposed.IsNull() && proposed.IsWhollyKnown() {
+		proposedMap = proposed.AsValueMap()
+	}
+
+	changed := map[string]struct{}{}
+	for key, p := range priorMap {
+		if n, ok := proposedMap[key]; !ok || !p.RawEquals(n) {
+			changed[key] = struct{}{}
+		}
+	}
+	for key, n := range proposedMap {
+		if p, ok := priorMap[key]; !ok || !p.RawEquals(n) {
+			changed[key] = struct{}{}
+		}
+	}
+
+	var keys []string
+	for key := range changed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 var testUUIDHook func() string
 
 func applyDataResourceChange(req providers.ApplyResourceChangeRequest) (resp providers.ApplyResourceChangeResponse) {
@@ -118,26 +308,87 @@ func applyDataResourceChange(req providers.ApplyResourceChangeRequest) (resp pro
 		newState["output"] = req.PlannedState.GetAttr("input")
 	}
 
+	if !req.PlannedState.GetAttr("output_json").IsKnown() {
+		input := req.PlannedState.GetAttr("input")
+
+		if input.IsNull() {
+			newState["output_json"] = cty.NullVal(cty.String)
+		} else {
+			declaredType := input.Type()
+			if outputType := req.PlannedState.GetAttr("output_type"); !outputType.IsNull() {
+				if ty, err := ctyjson.UnmarshalType([]byte(outputType.AsString())); err == nil {
+					declaredType = ty
+				}
+			}
+
+			// validateDataResourceConfig already confirmed input conforms to
+			// output_type, but conversion can still coerce between
+			// compatible kinds (e.g. number to string), so convert before
+			// marshaling rather than relying on Marshal to do it implicitly.
+			converted, err := convert.Convert(input, declaredType)
+			if err != nil {
+				converted = input
+			}
+
+			j, err := ctyjson.Marshal(converted, converted.Type())
+			if err != nil {
+				diag := tfdiags.AttributeValue(
+					tfdiags.Error,
+					"Error encoding output_json",
+					err.Error(),
+					cty.GetAttrPath("output_json"),
+				)
+
+				resp.Diagnostics = resp.Diagnostics.Append(diag)
+			}
+
+			newState["output_json"] = cty.StringVal(string(j))
+		}
+	}
+
 	if !req.PlannedState.GetAttr("uuid").IsKnown() {
-		uuidString, err := uuid.GenerateUUID()
-		// Terraform would probably never get this far without a good random
-		// source, but catch the error anyway.
-		if err != nil {
-			diag := tfdiags.AttributeValue(
-				tfdiags.Error,
-				"Error generating uuid",
-				err.Error(),
-				cty.GetAttrPath("uuid"),
-			)
-
-			resp.Diagnostics = resp.Diagnostics.Append(diag)
+		mode := "uuid"
+		if idMode := req.PlannedState.GetAttr("id_mode"); !idMode.IsNull() {
+			mode = idMode.AsString()
 		}
 
-		if testUUIDHook != nil {
-			uuidString = testUUIDHook()
+		var idString string
+		if mode == "uuid" {
+			generated, err := uuid.GenerateUUID()
+			// Terraform would probably never get this far without a good
+			// random source, but catch the error anyway.
+			if err != nil {
+				diag := tfdiags.AttributeValue(
+					tfdiags.Error,
+					"Error generating uuid",
+					err.Error(),
+					cty.GetAttrPath("uuid"),
+				)
+
+				resp.Diagnostics = resp.Diagnostics.Append(diag)
+			}
+
+			idString = generated
+			if testUUIDHook != nil {
+				idString = testUUIDHook()
+			}
+		} else {
+			hashed, err := hashID(mode, req.PlannedState.GetAttr("input"), req.PlannedState.GetAttr("trigger"), req.PlannedState.GetAttr("triggers_replace"))
+			if err != nil {
+				diag := tfdiags.AttributeValue(
+					tfdiags.Error,
+					"Error computing id",
+					err.Error(),
+					cty.GetAttrPath("uuid"),
+				)
+
+				resp.Diagnostics = resp.Diagnostics.Append(diag)
+			}
+
+			idString = hashed
 		}
 
-		newState["uuid"] = cty.StringVal(uuidString)
+		newState["uuid"] = cty.StringVal(idString)
 	}
 
 	resp.NewState = cty.ObjectVal(newState)
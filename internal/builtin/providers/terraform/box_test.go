@@ -0,0 +1,515 @@
+package terraform
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/internal/providers"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// testResourceVal builds a complete terraform_data object value, with every
+// attribute defaulted to its "unset" value, so individual tests only need to
+// specify the attributes they care about.
+func testResourceVal(overrides map[string]cty.Value) cty.Value {
+	attrs := map[string]cty.Value{
+		"id_mode":          cty.NullVal(cty.String),
+		"input":            cty.NullVal(cty.DynamicPseudoType),
+		"output":           cty.NullVal(cty.DynamicPseudoType),
+		"output_type":      cty.NullVal(cty.String),
+		"output_json":      cty.NullVal(cty.String),
+		"trigger":          cty.NullVal(cty.DynamicPseudoType),
+		"triggers_replace": cty.NullVal(cty.Map(cty.String)),
+		"uuid":             cty.NullVal(cty.String),
+	}
+	for k, v := range overrides {
+		attrs[k] = v
+	}
+	return cty.ObjectVal(attrs)
+}
+
+func wantErrContains(t *testing.T, diags tfdiags.Diagnostics, want string) {
+	t.Helper()
+	if want == "" {
+		if diags.HasErrors() {
+			t.Fatalf("unexpected error: %s", diags.Err())
+		}
+		return
+	}
+	if !diags.HasErrors() {
+		t.Fatalf("expected error containing %q, got none", want)
+	}
+	if !strings.Contains(diags.Err().Error(), want) {
+		t.Fatalf("expected error containing %q, got %q", want, diags.Err())
+	}
+}
+
+func TestValidateDataResourceConfig(t *testing.T) {
+	tests := map[string]struct {
+		config  cty.Value
+		wantErr string
+	}{
+		"trigger and triggers_replace mutually exclusive": {
+			config: testResourceVal(map[string]cty.Value{
+				"trigger":          cty.StringVal("a"),
+				"triggers_replace": cty.MapVal(map[string]cty.Value{"k": cty.StringVal("v")}),
+			}),
+			wantErr: "mutually exclusive",
+		},
+		"trigger alone is fine": {
+			config: testResourceVal(map[string]cty.Value{
+				"trigger": cty.StringVal("a"),
+			}),
+		},
+		"triggers_replace alone is fine": {
+			config: testResourceVal(map[string]cty.Value{
+				"triggers_replace": cty.MapVal(map[string]cty.Value{"k": cty.StringVal("v")}),
+			}),
+		},
+		"invalid id_mode": {
+			config: testResourceVal(map[string]cty.Value{
+				"id_mode": cty.StringVal("bogus"),
+			}),
+			wantErr: `"id_mode" must be one of`,
+		},
+		"unknown id_mode does not panic": {
+			config: testResourceVal(map[string]cty.Value{
+				"id_mode": cty.UnknownVal(cty.String),
+			}),
+		},
+		"unknown output_type does not panic": {
+			config: testResourceVal(map[string]cty.Value{
+				"output_type": cty.UnknownVal(cty.String),
+			}),
+		},
+		"invalid output_type": {
+			config: testResourceVal(map[string]cty.Value{
+				"output_type": cty.StringVal("not valid json"),
+			}),
+			wantErr: `invalid "output_type"`,
+		},
+		"input does not conform to output_type": {
+			config: testResourceVal(map[string]cty.Value{
+				"input":       cty.ObjectVal(map[string]cty.Value{"a": cty.StringVal("b")}),
+				"output_type": cty.StringVal(`"number"`),
+			}),
+			wantErr: `does not conform to "output_type"`,
+		},
+		"input conforms to output_type": {
+			config: testResourceVal(map[string]cty.Value{
+				"input":       cty.NumberIntVal(1),
+				"output_type": cty.StringVal(`"string"`),
+			}),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp := validateDataResourceConfig(providers.ValidateResourceConfigRequest{
+				Config: tc.config,
+			})
+			wantErrContains(t, resp.Diagnostics, tc.wantErr)
+		})
+	}
+}
+
+func TestChangedTriggerKeys(t *testing.T) {
+	tests := map[string]struct {
+		prior, proposed cty.Value
+		want            []string
+	}{
+		"both null": {
+			prior:    cty.NullVal(cty.Map(cty.String)),
+			proposed: cty.NullVal(cty.Map(cty.String)),
+			want:     nil,
+		},
+		"key added": {
+			prior: cty.NullVal(cty.Map(cty.String)),
+			proposed: cty.MapVal(map[string]cty.Value{
+				"db_version": cty.StringVal("1"),
+			}),
+			want: []string{"db_version"},
+		},
+		"key changed": {
+			prior: cty.MapVal(map[string]cty.Value{
+				"db_version": cty.StringVal("1"),
+				"other":      cty.StringVal("x"),
+			}),
+			proposed: cty.MapVal(map[string]cty.Value{
+				"db_version": cty.StringVal("2"),
+				"other":      cty.StringVal("x"),
+			}),
+			want: []string{"db_version"},
+		},
+		"key removed": {
+			prior: cty.MapVal(map[string]cty.Value{
+				"db_version": cty.StringVal("1"),
+			}),
+			proposed: cty.NullVal(cty.Map(cty.String)),
+			want:     []string{"db_version"},
+		},
+		"unchanged": {
+			prior: cty.MapVal(map[string]cty.Value{
+				"db_version": cty.StringVal("1"),
+			}),
+			proposed: cty.MapVal(map[string]cty.Value{
+				"db_version": cty.StringVal("1"),
+			}),
+			want: nil,
+		},
+		"proposed wholly unknown": {
+			// changedTriggerKeys must not panic calling AsValueMap() on an
+			// unknown value; planDataResourceChange guards against calling
+			// it at all in this case, but the helper is defensive on its
+			// own too.
+			prior: cty.MapVal(map[string]cty.Value{
+				"db_version": cty.StringVal("1"),
+			}),
+			proposed: cty.UnknownVal(cty.Map(cty.String)),
+			want:     []string{"db_version"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := changedTriggerKeys(tc.prior, tc.proposed)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPlanDataResourceChangeTriggersReplace(t *testing.T) {
+	prior := testResourceVal(map[string]cty.Value{
+		"input":  cty.StringVal("hello"),
+		"output": cty.StringVal("hello"),
+		"triggers_replace": cty.MapVal(map[string]cty.Value{
+			"db_version": cty.StringVal("1"),
+		}),
+		"uuid": cty.StringVal("existing-uuid"),
+	})
+
+	proposed := testResourceVal(map[string]cty.Value{
+		"input":       cty.StringVal("hello"),
+		"output":      cty.UnknownVal(cty.DynamicPseudoType),
+		"output_json": cty.UnknownVal(cty.String),
+		"triggers_replace": cty.MapVal(map[string]cty.Value{
+			"db_version": cty.StringVal("2"),
+		}),
+		"uuid": cty.UnknownVal(cty.String),
+	})
+
+	resp := planDataResourceChange(providers.PlanResourceChangeRequest{
+		PriorState:       prior,
+		ProposedNewState: proposed,
+	})
+
+	if len(resp.RequiresReplace) != 1 {
+		t.Fatalf("expected exactly one RequiresReplace path, got %d: %#v", len(resp.RequiresReplace), resp.RequiresReplace)
+	}
+
+	want := cty.GetAttrPath("triggers_replace").IndexString("db_version")
+	if !reflect.DeepEqual(resp.RequiresReplace[0], want) {
+		t.Fatalf("got path %#v, want %#v", resp.RequiresReplace[0], want)
+	}
+}
+
+func TestPlanDataResourceChangeTriggersReplaceUnknown(t *testing.T) {
+	prior := testResourceVal(map[string]cty.Value{
+		"input":  cty.StringVal("hello"),
+		"output": cty.StringVal("hello"),
+		"triggers_replace": cty.MapVal(map[string]cty.Value{
+			"db_version": cty.StringVal("1"),
+		}),
+		"uuid": cty.StringVal("existing-uuid"),
+	})
+
+	proposed := testResourceVal(map[string]cty.Value{
+		"input":            cty.StringVal("hello"),
+		"output":           cty.UnknownVal(cty.DynamicPseudoType),
+		"output_json":      cty.UnknownVal(cty.String),
+		"triggers_replace": cty.UnknownVal(cty.Map(cty.String)),
+		"uuid":             cty.UnknownVal(cty.String),
+	})
+
+	resp := planDataResourceChange(providers.PlanResourceChangeRequest{
+		PriorState:       prior,
+		ProposedNewState: proposed,
+	})
+
+	if len(resp.RequiresReplace) != 1 {
+		t.Fatalf("expected exactly one RequiresReplace path, got %d: %#v", len(resp.RequiresReplace), resp.RequiresReplace)
+	}
+
+	want := cty.GetAttrPath("triggers_replace")
+	if !reflect.DeepEqual(resp.RequiresReplace[0], want) {
+		t.Fatalf("got path %#v, want %#v", resp.RequiresReplace[0], want)
+	}
+}
+
+func TestPlanDataResourceChangeIDMode(t *testing.T) {
+	t.Run("create with sha256 and known input computes the id at plan time", func(t *testing.T) {
+		proposed := testResourceVal(map[string]cty.Value{
+			"id_mode":     cty.StringVal("sha256"),
+			"input":       cty.StringVal("hello"),
+			"output":      cty.UnknownVal(cty.DynamicPseudoType),
+			"output_json": cty.UnknownVal(cty.String),
+			"uuid":        cty.UnknownVal(cty.String),
+		})
+
+		resp := planDataResourceChange(providers.PlanResourceChangeRequest{
+			PriorState:       cty.NullVal(cty.DynamicPseudoType),
+			ProposedNewState: proposed,
+		})
+
+		uuid := resp.PlannedState.GetAttr("uuid")
+		if !uuid.IsKnown() {
+			t.Fatal("expected uuid to be known at plan time for sha256 id_mode")
+		}
+	})
+
+	t.Run("create with default uuid mode leaves the id unknown", func(t *testing.T) {
+		proposed := testResourceVal(map[string]cty.Value{
+			"input":       cty.StringVal("hello"),
+			"output":      cty.UnknownVal(cty.DynamicPseudoType),
+			"output_json": cty.UnknownVal(cty.String),
+			"uuid":        cty.UnknownVal(cty.String),
+		})
+
+		resp := planDataResourceChange(providers.PlanResourceChangeRequest{
+			PriorState:       cty.NullVal(cty.DynamicPseudoType),
+			ProposedNewState: proposed,
+		})
+
+		if resp.PlannedState.GetAttr("uuid").IsKnown() {
+			t.Fatal("expected uuid to remain unknown at plan time for the default uuid id_mode")
+		}
+	})
+
+	t.Run("input change recomputes the hash id without a replace", func(t *testing.T) {
+		prior := testResourceVal(map[string]cty.Value{
+			"id_mode":     cty.StringVal("sha256"),
+			"input":       cty.StringVal("a"),
+			"output":      cty.StringVal("a"),
+			"output_json": cty.StringVal(`"a"`),
+			"uuid":        cty.StringVal("stale-hash"),
+		})
+
+		proposed := testResourceVal(map[string]cty.Value{
+			"id_mode":     cty.StringVal("sha256"),
+			"input":       cty.StringVal("b"),
+			"output":      cty.UnknownVal(cty.DynamicPseudoType),
+			"output_json": cty.UnknownVal(cty.String),
+			"uuid":        prior.GetAttr("uuid"),
+		})
+
+		resp := planDataResourceChange(providers.PlanResourceChangeRequest{
+			PriorState:       prior,
+			ProposedNewState: proposed,
+		})
+
+		if len(resp.RequiresReplace) != 0 {
+			t.Fatalf("expected no replacement for a content-addressable id, got %#v", resp.RequiresReplace)
+		}
+
+		got := resp.PlannedState.GetAttr("uuid")
+		if !got.IsKnown() {
+			t.Fatal("expected the recomputed id to be known at plan time")
+		}
+		if got.RawEquals(prior.GetAttr("uuid")) {
+			t.Fatal("expected the id to change alongside input")
+		}
+	})
+
+	t.Run("input change in default uuid mode leaves the persisted id untouched", func(t *testing.T) {
+		prior := testResourceVal(map[string]cty.Value{
+			"input":       cty.StringVal("a"),
+			"output":      cty.StringVal("a"),
+			"output_json": cty.NullVal(cty.String),
+			"uuid":        cty.StringVal("existing-uuid"),
+		})
+
+		proposed := testResourceVal(map[string]cty.Value{
+			"input":       cty.StringVal("b"),
+			"output":      cty.UnknownVal(cty.DynamicPseudoType),
+			"output_json": cty.UnknownVal(cty.String),
+			"uuid":        cty.StringVal("existing-uuid"),
+		})
+
+		resp := planDataResourceChange(providers.PlanResourceChangeRequest{
+			PriorState:       prior,
+			ProposedNewState: proposed,
+		})
+
+		got := resp.PlannedState.GetAttr("uuid")
+		if !got.RawEquals(cty.StringVal("existing-uuid")) {
+			t.Fatalf("expected uuid to be left untouched, got %#v", got)
+		}
+	})
+
+	t.Run("id_mode change alone recomputes the id without an input change", func(t *testing.T) {
+		prior := testResourceVal(map[string]cty.Value{
+			"input":       cty.StringVal("a"),
+			"output":      cty.StringVal("a"),
+			"output_json": cty.NullVal(cty.String),
+			"uuid":        cty.StringVal("existing-uuid"),
+		})
+
+		proposed := testResourceVal(map[string]cty.Value{
+			"id_mode":     cty.StringVal("sha256"),
+			"input":       cty.StringVal("a"),
+			"output":      cty.StringVal("a"),
+			"output_json": cty.NullVal(cty.String),
+			"uuid":        cty.StringVal("existing-uuid"),
+		})
+
+		resp := planDataResourceChange(providers.PlanResourceChangeRequest{
+			PriorState:       prior,
+			ProposedNewState: proposed,
+		})
+
+		if len(resp.RequiresReplace) != 0 {
+			t.Fatalf("expected no replacement for an id_mode-only change, got %#v", resp.RequiresReplace)
+		}
+
+		got := resp.PlannedState.GetAttr("uuid")
+		if !got.IsKnown() {
+			t.Fatal("expected the id to be recomputed at plan time under the new id_mode")
+		}
+		if got.RawEquals(prior.GetAttr("uuid")) {
+			t.Fatal("expected the id to change alongside id_mode even though input didn't change")
+		}
+	})
+}
+
+func TestPlanDataResourceChangeOutputType(t *testing.T) {
+	t.Run("output_type change alone marks output_json unknown without an input change", func(t *testing.T) {
+		prior := testResourceVal(map[string]cty.Value{
+			"input":       cty.StringVal("hello"),
+			"output":      cty.StringVal("hello"),
+			"output_json": cty.StringVal(`"hello"`),
+			"uuid":        cty.StringVal("existing-uuid"),
+		})
+
+		proposed := testResourceVal(map[string]cty.Value{
+			"input":       cty.StringVal("hello"),
+			"output":      cty.StringVal("hello"),
+			"output_type": cty.StringVal(`"string"`),
+			"output_json": prior.GetAttr("output_json"),
+			"uuid":        cty.StringVal("existing-uuid"),
+		})
+
+		resp := planDataResourceChange(providers.PlanResourceChangeRequest{
+			PriorState:       prior,
+			ProposedNewState: proposed,
+		})
+
+		if resp.PlannedState.GetAttr("output_json").IsKnown() {
+			t.Fatal("expected output_json to be left unknown when output_type changes")
+		}
+	})
+}
+
+func TestApplyDataResourceChangeIDMode(t *testing.T) {
+	t.Run("sha256 id_mode hashes input and trigger deterministically", func(t *testing.T) {
+		planned := testResourceVal(map[string]cty.Value{
+			"id_mode": cty.StringVal("sha256"),
+			"input":   cty.StringVal("hello"),
+			"output":  cty.UnknownVal(cty.DynamicPseudoType),
+			"trigger": cty.StringVal("t"),
+			"uuid":    cty.UnknownVal(cty.String),
+		})
+
+		resp := applyDataResourceChange(providers.ApplyResourceChangeRequest{
+			PlannedState: planned,
+		})
+
+		want, err := hashID("sha256", cty.StringVal("hello"), cty.StringVal("t"), cty.NullVal(cty.Map(cty.String)))
+		if err != nil {
+			t.Fatalf("hashID: %s", err)
+		}
+
+		got := resp.NewState.GetAttr("uuid")
+		if !got.RawEquals(cty.StringVal(want)) {
+			t.Fatalf("got uuid %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("default uuid id_mode still generates a random uuid", func(t *testing.T) {
+		testUUIDHook = func() string { return "test-uuid" }
+		defer func() { testUUIDHook = nil }()
+
+		planned := testResourceVal(map[string]cty.Value{
+			"input":  cty.StringVal("hello"),
+			"output": cty.UnknownVal(cty.DynamicPseudoType),
+			"uuid":   cty.UnknownVal(cty.String),
+		})
+
+		resp := applyDataResourceChange(providers.ApplyResourceChangeRequest{
+			PlannedState: planned,
+		})
+
+		got := resp.NewState.GetAttr("uuid")
+		if !got.RawEquals(cty.StringVal("test-uuid")) {
+			t.Fatalf("got uuid %#v, want %#v", got, "test-uuid")
+		}
+	})
+}
+
+func TestApplyDataResourceChangeOutputJSON(t *testing.T) {
+	t.Run("output_json is marshaled using the declared output_type", func(t *testing.T) {
+		planned := testResourceVal(map[string]cty.Value{
+			"input":       cty.NumberIntVal(1),
+			"output":      cty.UnknownVal(cty.DynamicPseudoType),
+			"output_type": cty.StringVal(`"string"`),
+			"output_json": cty.UnknownVal(cty.String),
+			"uuid":        cty.StringVal("existing-uuid"),
+		})
+
+		resp := applyDataResourceChange(providers.ApplyResourceChangeRequest{
+			PlannedState: planned,
+		})
+
+		got := resp.NewState.GetAttr("output_json")
+		if !got.RawEquals(cty.StringVal(`"1"`)) {
+			t.Fatalf("got output_json %#v, want %#v", got, `"1"`)
+		}
+	})
+
+	t.Run("output_json falls back to the input's own type without output_type", func(t *testing.T) {
+		planned := testResourceVal(map[string]cty.Value{
+			"input":       cty.NumberIntVal(1),
+			"output":      cty.UnknownVal(cty.DynamicPseudoType),
+			"output_json": cty.UnknownVal(cty.String),
+			"uuid":        cty.StringVal("existing-uuid"),
+		})
+
+		resp := applyDataResourceChange(providers.ApplyResourceChangeRequest{
+			PlannedState: planned,
+		})
+
+		got := resp.NewState.GetAttr("output_json")
+		if !got.RawEquals(cty.StringVal(`1`)) {
+			t.Fatalf("got output_json %#v, want %#v", got, `1`)
+		}
+	})
+
+	t.Run("output_json is null when input is null", func(t *testing.T) {
+		planned := testResourceVal(map[string]cty.Value{
+			"output_json": cty.UnknownVal(cty.String),
+			"uuid":        cty.StringVal("existing-uuid"),
+		})
+
+		resp := applyDataResourceChange(providers.ApplyResourceChangeRequest{
+			PlannedState: planned,
+		})
+
+		got := resp.NewState.GetAttr("output_json")
+		if !got.IsNull() {
+			t.Fatalf("got output_json %#v, want null", got)
+		}
+	})
+}